@@ -0,0 +1,37 @@
+package log_v1
+
+import (
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func grpcNotImplemented(method string) error {
+	return status.Errorf(codes.Unimplemented, "method %s not implemented", method)
+}
+
+// ErrOffsetOutOfRange is returned when a client asks to consume an offset
+// that doesn't exist in the log, either because it's been truncated away
+// or hasn't been produced yet. Lowest and Highest let the client reposition
+// itself without a second round trip.
+type ErrOffsetOutOfRange struct {
+	Offset  uint64
+	Lowest  uint64
+	Highest uint64
+}
+
+func (e ErrOffsetOutOfRange) GRPCStatus() *status.Status {
+	st := status.New(
+		codes.OutOfRange,
+		fmt.Sprintf(
+			"offset out of range: %d (lowest: %d, highest: %d)",
+			e.Offset, e.Lowest, e.Highest,
+		),
+	)
+	return st
+}
+
+func (e ErrOffsetOutOfRange) Error() string {
+	return e.GRPCStatus().Err().Error()
+}
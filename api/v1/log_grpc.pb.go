@@ -0,0 +1,266 @@
+// Hand-written to match the shape protoc-gen-go-grpc would produce from
+// api/v1/log.proto (the gRPC plugin isn't available in this build
+// environment). Keep it in sync with the Log service in log.proto by hand
+// until it can be regenerated for real.
+
+package log_v1
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// LogClient is the client API for Log service.
+type LogClient interface {
+	Produce(ctx context.Context, in *ProduceRequest, opts ...grpc.CallOption) (*ProduceResponse, error)
+	Consume(ctx context.Context, in *ConsumeRequest, opts ...grpc.CallOption) (*ConsumeResponse, error)
+	ProduceStream(ctx context.Context, opts ...grpc.CallOption) (Log_ProduceStreamClient, error)
+	ConsumeStream(ctx context.Context, in *ConsumeRequest, opts ...grpc.CallOption) (Log_ConsumeStreamClient, error)
+	GetServers(ctx context.Context, in *GetServersRequest, opts ...grpc.CallOption) (*GetServersResponse, error)
+}
+
+type logClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLogClient(cc grpc.ClientConnInterface) LogClient {
+	return &logClient{cc}
+}
+
+func (c *logClient) Produce(ctx context.Context, in *ProduceRequest, opts ...grpc.CallOption) (*ProduceResponse, error) {
+	out := new(ProduceResponse)
+	if err := c.cc.Invoke(ctx, "/log.v1.Log/Produce", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *logClient) Consume(ctx context.Context, in *ConsumeRequest, opts ...grpc.CallOption) (*ConsumeResponse, error) {
+	out := new(ConsumeResponse)
+	if err := c.cc.Invoke(ctx, "/log.v1.Log/Consume", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *logClient) ProduceStream(ctx context.Context, opts ...grpc.CallOption) (Log_ProduceStreamClient, error) {
+	stream, err := c.cc.(grpc.ClientConnInterface).NewStream(ctx, &Log_ServiceDesc.Streams[0], "/log.v1.Log/ProduceStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &logProduceStreamClient{stream}, nil
+}
+
+type Log_ProduceStreamClient interface {
+	Send(*ProduceRequest) error
+	Recv() (*ProduceResponse, error)
+	grpc.ClientStream
+}
+
+type logProduceStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *logProduceStreamClient) Send(m *ProduceRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *logProduceStreamClient) Recv() (*ProduceResponse, error) {
+	m := new(ProduceResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *logClient) ConsumeStream(ctx context.Context, in *ConsumeRequest, opts ...grpc.CallOption) (Log_ConsumeStreamClient, error) {
+	stream, err := c.cc.(grpc.ClientConnInterface).NewStream(ctx, &Log_ServiceDesc.Streams[1], "/log.v1.Log/ConsumeStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &logConsumeStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Log_ConsumeStreamClient interface {
+	Recv() (*ConsumeResponse, error)
+	grpc.ClientStream
+}
+
+type logConsumeStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *logConsumeStreamClient) Recv() (*ConsumeResponse, error) {
+	m := new(ConsumeResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *logClient) GetServers(ctx context.Context, in *GetServersRequest, opts ...grpc.CallOption) (*GetServersResponse, error) {
+	out := new(GetServersResponse)
+	if err := c.cc.Invoke(ctx, "/log.v1.Log/GetServers", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// LogServer is the server API for Log service.
+// Implementations must embed UnimplementedLogServer for forward compatibility.
+type LogServer interface {
+	Produce(context.Context, *ProduceRequest) (*ProduceResponse, error)
+	Consume(context.Context, *ConsumeRequest) (*ConsumeResponse, error)
+	ProduceStream(Log_ProduceStreamServer) error
+	ConsumeStream(*ConsumeRequest, Log_ConsumeStreamServer) error
+	GetServers(context.Context, *GetServersRequest) (*GetServersResponse, error)
+}
+
+// UnimplementedLogServer must be embedded for forward compatibility.
+type UnimplementedLogServer struct{}
+
+func (UnimplementedLogServer) Produce(context.Context, *ProduceRequest) (*ProduceResponse, error) {
+	return nil, grpcNotImplemented("Produce")
+}
+func (UnimplementedLogServer) Consume(context.Context, *ConsumeRequest) (*ConsumeResponse, error) {
+	return nil, grpcNotImplemented("Consume")
+}
+func (UnimplementedLogServer) ProduceStream(Log_ProduceStreamServer) error {
+	return grpcNotImplemented("ProduceStream")
+}
+func (UnimplementedLogServer) ConsumeStream(*ConsumeRequest, Log_ConsumeStreamServer) error {
+	return grpcNotImplemented("ConsumeStream")
+}
+func (UnimplementedLogServer) GetServers(context.Context, *GetServersRequest) (*GetServersResponse, error) {
+	return nil, grpcNotImplemented("GetServers")
+}
+
+type Log_ProduceStreamServer interface {
+	Send(*ProduceResponse) error
+	Recv() (*ProduceRequest, error)
+	grpc.ServerStream
+}
+
+type logProduceStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *logProduceStreamServer) Send(m *ProduceResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *logProduceStreamServer) Recv() (*ProduceRequest, error) {
+	m := new(ProduceRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+type Log_ConsumeStreamServer interface {
+	Send(*ConsumeResponse) error
+	grpc.ServerStream
+}
+
+type logConsumeStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *logConsumeStreamServer) Send(m *ConsumeResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func RegisterLogServer(s grpc.ServiceRegistrar, srv LogServer) {
+	s.RegisterService(&Log_ServiceDesc, srv)
+}
+
+func _Log_Produce_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProduceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LogServer).Produce(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/log.v1.Log/Produce"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LogServer).Produce(ctx, req.(*ProduceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Log_Consume_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConsumeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LogServer).Consume(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/log.v1.Log/Consume"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LogServer).Consume(ctx, req.(*ConsumeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Log_GetServers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetServersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LogServer).GetServers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/log.v1.Log/GetServers"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LogServer).GetServers(ctx, req.(*GetServersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Log_ProduceStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(LogServer).ProduceStream(&logProduceStreamServer{stream})
+}
+
+func _Log_ConsumeStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ConsumeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LogServer).ConsumeStream(m, &logConsumeStreamServer{stream})
+}
+
+// Log_ServiceDesc is the grpc.ServiceDesc for Log service, used by
+// RegisterLogServer and NewLogClient.
+var Log_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "log.v1.Log",
+	HandlerType: (*LogServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "Produce", Handler: _Log_Produce_Handler},
+		{MethodName: "Consume", Handler: _Log_Consume_Handler},
+		{MethodName: "GetServers", Handler: _Log_GetServers_Handler},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ProduceStream",
+			Handler:       _Log_ProduceStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ConsumeStream",
+			Handler:       _Log_ConsumeStream_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "log.proto",
+}
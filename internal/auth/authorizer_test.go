@@ -0,0 +1,22 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorizer(t *testing.T) {
+	authorizer, err := New(
+		"testdata/model.conf",
+		"testdata/policy.csv",
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, authorizer.Authorize("root", "*", "produce"))
+	require.NoError(t, authorizer.Authorize("root", "*", "consume"))
+	require.NoError(t, authorizer.Authorize("nobody", "*", "consume"))
+
+	err = authorizer.Authorize("nobody", "*", "produce")
+	require.Error(t, err)
+}
@@ -1,13 +1,77 @@
 package main
 
 import (
+	"context"
 	"log"
+	"net"
+	"net/http"
+	"os"
+	"time"
 
+	intlog "github.com/lipandr/go-microsrv-distib-log/internal/log"
 	"github.com/lipandr/go-microsrv-distib-log/internal/server"
+	"github.com/lipandr/go-microsrv-distib-log/internal/telemetry"
 )
 
+// telemetryExporter picks the exporter Setup installs, defaulting to
+// Prometheus (the exporter cheapest to run ad hoc: no collector needed,
+// just a GET /metrics). Set TELEMETRY_EXPORTER to "stdout" or "otlp" to
+// change it.
+func telemetryExporter() telemetry.Exporter {
+	if v := os.Getenv("TELEMETRY_EXPORTER"); v != "" {
+		return telemetry.Exporter(v)
+	}
+	return telemetry.ExporterPrometheus
+}
+
 func main() {
+	shutdown, metricsHandler, err := telemetry.Setup(context.Background(), telemetry.Config{
+		ServiceName:  "go-microsrv-distib-log",
+		Exporter:     telemetryExporter(),
+		OTLPEndpoint: os.Getenv("TELEMETRY_OTLP_ENDPOINT"),
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = shutdown(ctx)
+	}()
+
+	// TLSConfig and Authorizer are left unset here: this single-node,
+	// no-auth setup is what internal/server falls back to when neither
+	// is configured. A deployment that needs mutual TLS and Casbin ACLs
+	// builds a *server.Config with both set instead.
+	config := &server.Config{}
+
 	// Create a new server
-	srv := server.New(":8080")
-	log.Fatal(srv.ListenAndServe())
+	srv := server.New(":8080", config)
+	go func() {
+		log.Fatal(srv.ListenAndServe())
+	}()
+
+	if metricsHandler != nil {
+		go func() {
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", metricsHandler)
+			log.Fatal(http.ListenAndServe(":2112", mux))
+		}()
+	}
+
+	clog, err := intlog.NewLog("/var/run/distlog", intlog.Config{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	config.CommitLog = clog
+
+	lis, err := net.Listen("tcp", ":8400")
+	if err != nil {
+		log.Fatal(err)
+	}
+	gsrv, err := server.NewGRPCServer(config)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Fatal(gsrv.Serve(lis))
 }
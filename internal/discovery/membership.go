@@ -0,0 +1,133 @@
+// Package discovery uses hashicorp/serf to let nodes find each other over
+// gossip, so the cluster doesn't need a static list of peers configured
+// up front: a node joins by pointing at any existing member, and from
+// then on learns about (and is told about) every other member's joins
+// and leaves.
+package discovery
+
+import (
+	"log"
+	"net"
+
+	"github.com/hashicorp/serf/serf"
+)
+
+// Handler reacts to membership changes. *distributed.Log implements it by
+// calling Join/Leave on its Raft membership, so a node added to the serf
+// cluster is also added as a Raft voter.
+type Handler interface {
+	Join(id, addr string) error
+	Leave(id string) error
+}
+
+// Config configures the serf agent a Membership runs.
+type Config struct {
+	NodeName       string
+	BindAddr       string
+	Tags           map[string]string
+	StartJoinAddrs []string
+}
+
+// Membership wraps serf.Serf, forwarding join and leave events for other
+// members to the configured Handler.
+type Membership struct {
+	Config
+	handler Handler
+	serf    *serf.Serf
+	events  chan serf.Event
+}
+
+// New creates a Membership with the given Handler and joins the serf
+// cluster described by config.
+func New(handler Handler, config Config) (*Membership, error) {
+	m := &Membership{
+		Config:  config,
+		handler: handler,
+	}
+	if err := m.setupSerf(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *Membership) setupSerf() (err error) {
+	addr, err := net.ResolveTCPAddr("tcp", m.BindAddr)
+	if err != nil {
+		return err
+	}
+	config := serf.DefaultConfig()
+	config.Init()
+	config.MemberlistConfig.BindAddr = addr.IP.String()
+	config.MemberlistConfig.BindPort = addr.Port
+	m.events = make(chan serf.Event)
+	config.EventCh = m.events
+	config.Tags = m.Tags
+	config.NodeName = m.Config.NodeName
+	m.serf, err = serf.Create(config)
+	if err != nil {
+		return err
+	}
+	go m.eventHandler()
+	if m.StartJoinAddrs != nil {
+		_, err = m.serf.Join(m.StartJoinAddrs, true)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Membership) eventHandler() {
+	for e := range m.events {
+		switch e.EventType() {
+		case serf.EventMemberJoin:
+			for _, member := range e.(serf.MemberEvent).Members {
+				if m.isLocal(member) {
+					continue
+				}
+				m.handleJoin(member)
+			}
+		case serf.EventMemberLeave, serf.EventMemberFailed:
+			for _, member := range e.(serf.MemberEvent).Members {
+				if m.isLocal(member) {
+					continue
+				}
+				m.handleLeave(member)
+			}
+		}
+	}
+}
+
+func (m *Membership) handleJoin(member serf.Member) {
+	if err := m.handler.Join(
+		member.Name,
+		member.Tags["rpc_addr"],
+	); err != nil {
+		m.logError(err, "failed to join", member)
+	}
+}
+
+func (m *Membership) handleLeave(member serf.Member) {
+	if err := m.handler.Leave(member.Name); err != nil {
+		m.logError(err, "failed to leave", member)
+	}
+}
+
+// isLocal returns whether the given member is the local member.
+func (m *Membership) isLocal(member serf.Member) bool {
+	return m.serf.LocalMember().Name == member.Name
+}
+
+// Members returns a point-in-time snapshot of the cluster's members.
+func (m *Membership) Members() []serf.Member {
+	return m.serf.Members()
+}
+
+// Leave tells this member to leave the serf cluster.
+func (m *Membership) Leave() error {
+	return m.serf.Leave()
+}
+
+func (m *Membership) logError(err error, msg string, member serf.Member) {
+	log.Printf("[ERROR] discovery: %s: %v, name=%s, rpc_addr=%s", msg, err, member.Name, member.Tags["rpc_addr"])
+}
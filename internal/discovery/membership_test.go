@@ -0,0 +1,117 @@
+package discovery
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeHandler records Join/Leave calls instead of actually wiring up a
+// Raft cluster, so the test can assert on membership events without
+// depending on distributed.Log.
+type fakeHandler struct {
+	mu     sync.Mutex
+	joins  map[string]string
+	leaves map[string]bool
+}
+
+func newFakeHandler() *fakeHandler {
+	return &fakeHandler{
+		joins:  make(map[string]string),
+		leaves: make(map[string]bool),
+	}
+}
+
+func (h *fakeHandler) Join(id, addr string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.joins[id] = addr
+	return nil
+}
+
+func (h *fakeHandler) Leave(id string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.leaves[id] = true
+	delete(h.joins, id)
+	return nil
+}
+
+func (h *fakeHandler) joined(id string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, ok := h.joins[id]
+	return ok
+}
+
+func (h *fakeHandler) left(id string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.leaves[id]
+}
+
+// newMember starts a Membership bound to a free local port, joining the
+// given startJoinAddrs, backed by its own fakeHandler.
+func newMember(t *testing.T, name string, startJoinAddrs []string) (*Membership, *fakeHandler) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	h := newFakeHandler()
+	m, err := New(h, Config{
+		NodeName:       name,
+		BindAddr:       addr,
+		Tags:           map[string]string{"rpc_addr": addr},
+		StartJoinAddrs: startJoinAddrs,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = m.Leave() })
+	return m, h
+}
+
+// eventually polls cond until it's true or the timeout elapses, so tests
+// don't have to guess how long gossip convergence takes.
+func eventually(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.True(t, cond())
+}
+
+// TestMembershipJoinAndLeave confirms that a node joining a cluster is
+// reported to every other member's Handler, and that leaving is too.
+func TestMembershipJoinAndLeave(t *testing.T) {
+	leader, leaderHandler := newMember(t, "leader", nil)
+
+	follower, followerHandler := newMember(t, "follower", []string{leader.BindAddr})
+
+	eventually(t, func() bool { return len(leader.Members()) == 2 })
+	eventually(t, func() bool { return leaderHandler.joined("follower") })
+	eventually(t, func() bool { return followerHandler.joined("leader") })
+
+	require.NoError(t, follower.Leave())
+	eventually(t, func() bool { return leaderHandler.left("follower") })
+}
+
+// TestMembershipIgnoresLocalMember confirms a node never reports its own
+// join to its own Handler.
+func TestMembershipIgnoresLocalMember(t *testing.T) {
+	leader, leaderHandler := newMember(t, "leader", nil)
+
+	require.Never(t, func() bool {
+		return leaderHandler.joined("leader")
+	}, 200*time.Millisecond, 20*time.Millisecond)
+
+	// Sanity check the cluster actually came up.
+	require.Equal(t, 1, len(leader.Members()))
+}
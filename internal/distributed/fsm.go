@@ -0,0 +1,167 @@
+package distributed
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+
+	"github.com/hashicorp/raft"
+	"google.golang.org/protobuf/proto"
+
+	api "github.com/lipandr/go-microsrv-distib-log/api/v1"
+	"github.com/lipandr/go-microsrv-distib-log/internal/log"
+)
+
+// lenWidth is the size, in bytes, of the length prefix fsm writes ahead
+// of each record's value in raft.Apply payloads, and of the segment and
+// frame length prefixes it reads back out of snapshots in Restore.
+const lenWidth = 8
+
+var enc = binary.BigEndian
+
+func marshalRequest(buf io.Writer, req interface{}) error {
+	switch r := req.(type) {
+	case *api.ProduceRequest:
+		if _, err := buf.Write([]byte{byte(appendRequestType)}); err != nil {
+			return err
+		}
+		return writeRecord(buf, r.Record)
+	default:
+		return io.ErrUnexpectedEOF
+	}
+}
+
+func writeRecord(w io.Writer, record *api.Record) error {
+	b := make([]byte, lenWidth)
+	enc.PutUint64(b, uint64(len(record.Value)))
+	if _, err := w.Write(b); err != nil {
+		return err
+	}
+	_, err := w.Write(record.Value)
+	return err
+}
+
+// readFrame reads an 8-byte big-endian length prefix followed by that
+// many bytes. It returns io.EOF, unaltered, when r is exhausted before
+// the length prefix itself - a clean end of stream - so callers can
+// treat it the same way bufio/io.ReadFull callers usually do.
+func readFrame(r io.Reader) ([]byte, error) {
+	b := make([]byte, lenWidth)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+	frame := make([]byte, enc.Uint64(b))
+	if _, err := io.ReadFull(r, frame); err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
+
+func readRecord(r io.Reader) (*api.Record, error) {
+	value, err := readFrame(r)
+	if err != nil {
+		return nil, err
+	}
+	return &api.Record{Value: value}, nil
+}
+
+// fsm is the Raft finite-state machine that applies committed log entries
+// to the node's local log.Log. Every node in the cluster, leader and
+// followers alike, runs Apply for the same sequence of entries, which is
+// what keeps their logs identical.
+type fsm struct {
+	log *log.Log
+}
+
+var _ raft.FSM = (*fsm)(nil)
+
+func (f *fsm) Apply(raftLog *raft.Log) interface{} {
+	buf := raftLog.Data
+	reqType := raft.LogType(buf[0])
+	switch reqType {
+	case appendRequestType:
+		return f.applyAppend(buf[1:])
+	}
+	return nil
+}
+
+func (f *fsm) applyAppend(b []byte) interface{} {
+	record, err := readRecord(bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	// Raft's Apply has no context of its own to thread through.
+	offset, err := f.log.Append(context.Background(), record)
+	if err != nil {
+		return err
+	}
+	return &api.ProduceResponse{Offset: offset}
+}
+
+// Snapshot streams every segment's store bytes through log.Log.Reader()
+// into a Raft snapshot, so a restoring node doesn't need to replay the
+// entire Raft log from the beginning.
+func (f *fsm) Snapshot() (raft.FSMSnapshot, error) {
+	r := f.log.Reader()
+	return &snapshot{reader: r}, nil
+}
+
+// Restore replaces the local log's contents with what Persist wrote: a
+// sequence of [8-byte length][segment bytes] blocks, one per segment
+// Log.Reader streamed, where each segment's bytes are its raw store
+// file - a 1-byte codec header followed by [8-byte length][codec-encoded
+// proto.Marshal(Record)] frames. It resets the log, decodes every record
+// out of every segment block in order, and replays each one back through
+// Append.
+func (f *fsm) Restore(r io.ReadCloser) error {
+	if err := f.log.Reset(); err != nil {
+		return err
+	}
+	for {
+		segment, err := readFrame(r)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := f.restoreSegment(segment); err != nil {
+			return err
+		}
+	}
+}
+
+// restoreSegment decodes and replays every record out of one segment's
+// raw store bytes, as produced by log.Log.Reader().
+func (f *fsm) restoreSegment(b []byte) error {
+	r := bytes.NewReader(b)
+	header := make([]byte, 1)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return err
+	}
+	codec, err := log.CodecByID(header[0])
+	if err != nil {
+		return err
+	}
+	for {
+		frame, err := readFrame(r)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		value, err := codec.Decode(bytes.NewReader(frame))
+		if err != nil {
+			return err
+		}
+		record := &api.Record{}
+		if err := proto.Unmarshal(value, record); err != nil {
+			return err
+		}
+		if _, err := f.log.Append(context.Background(), record); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,260 @@
+// Package distributed wraps log.Log in a hashicorp/raft finite-state
+// machine so a commit log can be replicated across a cluster of nodes:
+// writes go through Raft's Apply on the leader, and Raft replays the
+// committed entries into each follower's local log.
+package distributed
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hashicorp/raft"
+	raftboltdb "github.com/hashicorp/raft-boltdb"
+
+	api "github.com/lipandr/go-microsrv-distib-log/api/v1"
+	"github.com/lipandr/go-microsrv-distib-log/internal/log"
+)
+
+// Config configures the Raft node backing a Log.
+type Config struct {
+	Raft struct {
+		raft.Config
+		StreamLayer *StreamLayer
+		Bootstrap   bool
+	}
+}
+
+// Log is a Raft-replicated commit log: Append goes through Raft
+// consensus, while Read is served from the local on-disk log.Log.
+type Log struct {
+	config Config
+
+	log        *log.Log
+	raft       *raft.Raft
+	membership *Membership
+}
+
+// NewLog creates the on-disk log and the Raft node that replicates it.
+func NewLog(dataDir string, config Config) (*Log, error) {
+	l := &Log{
+		config: config,
+	}
+	if err := l.setupLog(dataDir); err != nil {
+		return nil, err
+	}
+	if err := l.setupRaft(dataDir); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *Log) setupLog(dataDir string) error {
+	logDir := filepath.Join(dataDir, "log")
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		return err
+	}
+	var err error
+	l.log, err = log.NewLog(logDir, log.Config{})
+	return err
+}
+
+func (l *Log) setupRaft(dataDir string) error {
+	fsm := &fsm{log: l.log}
+
+	logDir := filepath.Join(dataDir, "raft", "log")
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		return err
+	}
+	logConfig := log.Config{}
+	logConfig.Segment.InitialOffset = 1
+	logStore, err := newLogStore(logDir, logConfig)
+	if err != nil {
+		return err
+	}
+
+	stableStore, err := raftboltdb.NewBoltStore(
+		filepath.Join(dataDir, "raft", "stable"),
+	)
+	if err != nil {
+		return err
+	}
+
+	retain := 1
+	snapshotStore, err := raft.NewFileSnapshotStore(
+		filepath.Join(dataDir, "raft"),
+		retain,
+		os.Stderr,
+	)
+	if err != nil {
+		return err
+	}
+
+	maxPool := 5
+	timeout := 10 * time.Second
+	transport := raft.NewNetworkTransport(
+		l.config.Raft.StreamLayer,
+		maxPool,
+		timeout,
+		os.Stderr,
+	)
+
+	config := l.config.Raft.Config
+	if config.LocalID == "" {
+		config.LocalID = raft.ServerID(transport.LocalAddr())
+	}
+
+	l.raft, err = raft.NewRaft(
+		&config,
+		fsm,
+		logStore,
+		stableStore,
+		snapshotStore,
+		transport,
+	)
+	if err != nil {
+		return err
+	}
+
+	hasState, err := raft.HasExistingState(
+		logStore, stableStore, snapshotStore,
+	)
+	if err != nil {
+		return err
+	}
+	if l.config.Raft.Bootstrap && !hasState {
+		raftConfig := raft.Configuration{
+			Servers: []raft.Server{{
+				ID:      config.LocalID,
+				Address: raft.ServerAddress(transport.LocalAddr()),
+			}},
+		}
+		err = l.raft.BootstrapCluster(raftConfig).Error()
+	}
+	l.membership = newMembership(l.raft)
+	return err
+}
+
+const (
+	appendRequestType raft.LogType = raft.LogCommand
+)
+
+// Append replicates a record through Raft: it's only durable once a
+// quorum of the cluster has committed it, at which point the leader's
+// FSM has applied it to its local log; followers apply it to theirs as
+// the commit reaches them, so they may briefly lag. ctx isn't
+// threaded into Raft's own Apply, which has no context parameter of its
+// own; it's accepted so Log satisfies internal/server's CommitLog.
+func (l *Log) Append(ctx context.Context, record *api.Record) (uint64, error) {
+	res, err := l.apply(appendRequestType, &api.ProduceRequest{Record: record})
+	if err != nil {
+		return 0, err
+	}
+	return res.(*api.ProduceResponse).Offset, nil
+}
+
+func (l *Log) apply(reqType raft.LogType, req interface{}) (interface{}, error) {
+	var buf bytes.Buffer
+	if err := marshalRequest(&buf, req); err != nil {
+		return nil, err
+	}
+	timeout := 10 * time.Second
+	future := l.raft.Apply(buf.Bytes(), timeout)
+	if future.Error() != nil {
+		return nil, future.Error()
+	}
+	res := future.Response()
+	if err, ok := res.(error); ok {
+		return nil, err
+	}
+	return res, nil
+}
+
+// LowestOffset delegates to the local commit log.
+func (l *Log) LowestOffset() (uint64, error) {
+	return l.log.LowestOffset()
+}
+
+// HighestOffset delegates to the local commit log.
+func (l *Log) HighestOffset() (uint64, error) {
+	return l.log.HighestOffset()
+}
+
+// Read is served locally: followers may be slightly behind the leader,
+// so callers that need linearizable reads should go through the leader
+// or add a read-index barrier before calling Read.
+func (l *Log) Read(ctx context.Context, offset uint64) (*api.Record, error) {
+	return l.log.Read(ctx, offset)
+}
+
+// Join adds the server with the given id and rpc address as a voter.
+// Only the leader accepts Join calls; Raft rejects it on a follower.
+func (l *Log) Join(id, addr string) error {
+	return l.membership.Join(id, addr)
+}
+
+// Leave removes the server with the given id from the cluster.
+func (l *Log) Leave(id string) error {
+	return l.membership.Leave(id)
+}
+
+// WaitForLeader blocks until the cluster has elected a leader or timeout
+// elapses. Useful in tests that bootstrap a cluster and need to wait
+// before issuing writes.
+func (l *Log) WaitForLeader(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for time.Now().Before(deadline) {
+		if l.raft.Leader() != "" {
+			return nil
+		}
+		<-ticker.C
+	}
+	return fmt.Errorf("timed out waiting for leader")
+}
+
+// Close shuts down the Raft instance and closes the local log.
+func (l *Log) Close() error {
+	f := l.raft.Shutdown()
+	if err := f.Error(); err != nil {
+		return err
+	}
+	return l.log.Close()
+}
+
+// GetServers reports the cluster's current membership, flagging which
+// server is the leader, so clients can connect to it for writes.
+func (l *Log) GetServers() ([]*api.Server, error) {
+	return l.membership.Servers()
+}
+
+// StreamLayer implements raft.StreamLayer over a plain TCP listener, so
+// Raft's transport can dial and accept connections for this node.
+type StreamLayer struct {
+	ln net.Listener
+}
+
+func NewStreamLayer(ln net.Listener) *StreamLayer {
+	return &StreamLayer{ln: ln}
+}
+
+func (s *StreamLayer) Dial(addr raft.ServerAddress, timeout time.Duration) (net.Conn, error) {
+	return net.DialTimeout("tcp", string(addr), timeout)
+}
+
+func (s *StreamLayer) Accept() (net.Conn, error) {
+	return s.ln.Accept()
+}
+
+func (s *StreamLayer) Close() error {
+	return s.ln.Close()
+}
+
+func (s *StreamLayer) Addr() net.Addr {
+	return s.ln.Addr()
+}
@@ -0,0 +1,65 @@
+package distributed
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/require"
+
+	api "github.com/lipandr/go-microsrv-distib-log/api/v1"
+)
+
+// newTestLog bootstraps a single-node Raft cluster backed by a Log in a
+// fresh temp dir, with Raft's timeouts shortened so the test doesn't
+// spend real seconds waiting for an election.
+func newTestLog(t *testing.T) *Log {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+
+	c := Config{}
+	c.Raft.Config = *raft.DefaultConfig()
+	c.Raft.StreamLayer = NewStreamLayer(ln)
+	c.Raft.Bootstrap = true
+	c.Raft.HeartbeatTimeout = 50 * time.Millisecond
+	c.Raft.ElectionTimeout = 50 * time.Millisecond
+	c.Raft.LeaderLeaseTimeout = 50 * time.Millisecond
+	c.Raft.CommitTimeout = 5 * time.Millisecond
+	c.Raft.LocalID = raft.ServerID("1")
+
+	l, err := NewLog(t.TempDir(), c)
+	require.NoError(t, err)
+	require.NoError(t, l.WaitForLeader(3*time.Second))
+	t.Cleanup(func() { _ = l.Close() })
+	return l
+}
+
+// TestLogAppendRead confirms a record applied through Raft's FSM can be
+// read back from the local commit log it replicates into.
+func TestLogAppendRead(t *testing.T) {
+	l := newTestLog(t)
+
+	off, err := l.Append(context.Background(), &api.Record{Value: []byte("hello world")})
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), off)
+
+	record, err := l.Read(context.Background(), off)
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello world"), record.Value)
+}
+
+// TestLogGetServers checks that a freshly bootstrapped single-node
+// cluster reports itself as the sole member and the leader.
+func TestLogGetServers(t *testing.T) {
+	l := newTestLog(t)
+
+	servers, err := l.GetServers()
+	require.NoError(t, err)
+	require.Len(t, servers, 1)
+	require.Equal(t, "1", servers[0].Id)
+	require.True(t, servers[0].IsLeader)
+}
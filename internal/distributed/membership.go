@@ -0,0 +1,64 @@
+package distributed
+
+import (
+	"github.com/hashicorp/raft"
+
+	api "github.com/lipandr/go-microsrv-distib-log/api/v1"
+)
+
+// Membership wraps the Raft configuration calls a cluster member needs
+// to add or remove peers, so Log itself doesn't have to know about
+// raft.Configuration bookkeeping.
+type Membership struct {
+	raft *raft.Raft
+}
+
+func newMembership(r *raft.Raft) *Membership {
+	return &Membership{raft: r}
+}
+
+// Join adds the server with the given id and rpc address as a voter.
+// Only the leader accepts Join calls; Raft rejects it on a follower.
+func (m *Membership) Join(id, addr string) error {
+	configFuture := m.raft.GetConfiguration()
+	if err := configFuture.Error(); err != nil {
+		return err
+	}
+	serverID := raft.ServerID(id)
+	serverAddr := raft.ServerAddress(addr)
+	for _, srv := range configFuture.Configuration().Servers {
+		if srv.ID == serverID && srv.Address == serverAddr {
+			// server already joined
+			return nil
+		}
+		if srv.ID == serverID || srv.Address == serverAddr {
+			if err := m.Leave(id); err != nil {
+				return err
+			}
+		}
+	}
+	return m.raft.AddVoter(serverID, serverAddr, 0, 0).Error()
+}
+
+// Leave removes the server with the given id from the cluster.
+func (m *Membership) Leave(id string) error {
+	return m.raft.RemoveServer(raft.ServerID(id), 0, 0).Error()
+}
+
+// Servers reports the cluster's current membership, flagging which
+// server is the leader.
+func (m *Membership) Servers() ([]*api.Server, error) {
+	future := m.raft.GetConfiguration()
+	if err := future.Error(); err != nil {
+		return nil, err
+	}
+	var servers []*api.Server
+	for _, server := range future.Configuration().Servers {
+		servers = append(servers, &api.Server{
+			Id:       string(server.ID),
+			RpcAddr:  string(server.Address),
+			IsLeader: m.raft.Leader() == server.Address,
+		})
+	}
+	return servers, nil
+}
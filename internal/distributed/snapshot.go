@@ -0,0 +1,26 @@
+package distributed
+
+import (
+	"io"
+
+	"github.com/hashicorp/raft"
+)
+
+// snapshot adapts log.Log.Reader() to raft.FSMSnapshot: Persist just
+// copies the log's raw bytes into whatever sink Raft hands it (a file on
+// disk, or a connection to a node that's installing the snapshot).
+type snapshot struct {
+	reader io.Reader
+}
+
+var _ raft.FSMSnapshot = (*snapshot)(nil)
+
+func (s *snapshot) Persist(sink raft.SnapshotSink) error {
+	if _, err := io.Copy(sink, s.reader); err != nil {
+		_ = sink.Cancel()
+		return err
+	}
+	return sink.Close()
+}
+
+func (s *snapshot) Release() {}
@@ -0,0 +1,74 @@
+package distributed
+
+import (
+	"context"
+
+	"github.com/hashicorp/raft"
+
+	api "github.com/lipandr/go-microsrv-distib-log/api/v1"
+	"github.com/lipandr/go-microsrv-distib-log/internal/log"
+)
+
+// logStore adapts our log.Log to raft.LogStore, so Raft can use it to
+// persist its own replicated log (distinct from the commit log the FSM
+// applies entries into). The wrapped log.Config.Segment.InitialOffset is
+// set to 1 so Raft log indexes, which start at 1, line up with offsets.
+type logStore struct {
+	*log.Log
+}
+
+func newLogStore(dir string, c log.Config) (*logStore, error) {
+	l, err := log.NewLog(dir, c)
+	if err != nil {
+		return nil, err
+	}
+	return &logStore{l}, nil
+}
+
+var _ raft.LogStore = (*logStore)(nil)
+
+func (l *logStore) FirstIndex() (uint64, error) {
+	return l.LowestOffset()
+}
+
+func (l *logStore) LastIndex() (uint64, error) {
+	off, err := l.HighestOffset()
+	if err != nil {
+		return 0, err
+	}
+	return off, nil
+}
+
+func (l *logStore) GetLog(index uint64, out *raft.Log) error {
+	// raft.LogStore has no context of its own to thread through.
+	in, err := l.Read(context.Background(), index)
+	if err != nil {
+		return err
+	}
+	out.Data = in.Value
+	out.Index = in.Offset
+	out.Type = raft.LogType(in.Type)
+	out.Term = in.Term
+	return nil
+}
+
+func (l *logStore) StoreLog(record *raft.Log) error {
+	return l.StoreLogs([]*raft.Log{record})
+}
+
+func (l *logStore) StoreLogs(records []*raft.Log) error {
+	for _, record := range records {
+		if _, err := l.Append(context.Background(), &api.Record{
+			Value: record.Data,
+			Term:  record.Term,
+			Type:  uint32(record.Type),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *logStore) DeleteRange(min, max uint64) error {
+	return l.Truncate(max)
+}
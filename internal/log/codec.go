@@ -0,0 +1,121 @@
+package log
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Codec compresses and decompresses the bytes a segment writes to its
+// store file. The index always points at logical record boundaries, so
+// swapping codecs only changes what's stored between those boundaries,
+// not how records are addressed.
+//
+// Each segment's store file starts with a one-byte header identifying
+// the codec (ID) that wrote it, so a log whose Config.Segment.Codec
+// changes over time can still read segments written under the old one.
+type Codec interface {
+	Name() string
+	ID() byte
+	Encode(w io.Writer, p []byte) (int, error)
+	Decode(r io.Reader) ([]byte, error)
+}
+
+// Codec header IDs, one per segment store file.
+const (
+	CodecNoneID byte = iota
+	CodecGzipID
+	CodecZstdID
+)
+
+// CodecByID returns the Codec a segment header byte identifies.
+func CodecByID(id byte) (Codec, error) {
+	switch id {
+	case CodecNoneID:
+		return NoneCodec{}, nil
+	case CodecGzipID:
+		return GzipCodec{}, nil
+	case CodecZstdID:
+		return ZstdCodec{}, nil
+	default:
+		return nil, fmt.Errorf("log: unknown codec id %d", id)
+	}
+}
+
+// NoneCodec writes records uncompressed: the log's original raw
+// length-prefixed encoding.
+type NoneCodec struct{}
+
+func (NoneCodec) Name() string { return "none" }
+func (NoneCodec) ID() byte     { return CodecNoneID }
+
+func (NoneCodec) Encode(w io.Writer, p []byte) (int, error) {
+	return w.Write(p)
+}
+
+func (NoneCodec) Decode(r io.Reader) ([]byte, error) {
+	return io.ReadAll(r)
+}
+
+// GzipCodec compresses each frame with gzip. It gets the best
+// compression ratio of the three at the cost of the lowest write
+// throughput, which suits segments that have rolled and gone cold more
+// than the one actively being appended to.
+type GzipCodec struct{}
+
+func (GzipCodec) Name() string { return "gzip" }
+func (GzipCodec) ID() byte     { return CodecGzipID }
+
+func (GzipCodec) Encode(w io.Writer, p []byte) (int, error) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	if _, err := gw.Write(p); err != nil {
+		return 0, err
+	}
+	if err := gw.Close(); err != nil {
+		return 0, err
+	}
+	return w.Write(buf.Bytes())
+}
+
+func (GzipCodec) Decode(r io.Reader) ([]byte, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = gr.Close() }()
+	return io.ReadAll(gr)
+}
+
+// ZstdCodec compresses each frame with zstd: noticeably better write
+// throughput than gzip at a similar ratio, which is why it's the better
+// default for segments taking live writes of large text/JSON payloads.
+type ZstdCodec struct{}
+
+func (ZstdCodec) Name() string { return "zstd" }
+func (ZstdCodec) ID() byte     { return CodecZstdID }
+
+func (ZstdCodec) Encode(w io.Writer, p []byte) (int, error) {
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = enc.Close() }()
+	return w.Write(enc.EncodeAll(p, nil))
+}
+
+func (ZstdCodec) Decode(r io.Reader) ([]byte, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	dec, err := zstd.NewReader(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer dec.Close()
+	return dec.DecodeAll(b, nil)
+}
@@ -0,0 +1,70 @@
+package log
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	api "github.com/lipandr/go-microsrv-distib-log/api/v1"
+)
+
+func TestCodecs(t *testing.T) {
+	payload := []byte(`{"hello":"world"}`)
+	for _, codec := range []Codec{NoneCodec{}, GzipCodec{}, ZstdCodec{}} {
+		t.Run(codec.Name(), func(t *testing.T) {
+			var buf bytes.Buffer
+			_, err := codec.Encode(&buf, payload)
+			require.NoError(t, err)
+
+			got, err := codec.Decode(&buf)
+			require.NoError(t, err)
+			require.Equal(t, payload, got)
+		})
+	}
+}
+
+func TestCodecByID(t *testing.T) {
+	for _, codec := range []Codec{NoneCodec{}, GzipCodec{}, ZstdCodec{}} {
+		got, err := CodecByID(codec.ID())
+		require.NoError(t, err)
+		require.Equal(t, codec.Name(), got.Name())
+	}
+
+	_, err := CodecByID(0xFF)
+	require.Error(t, err)
+}
+
+// TestSegmentCodec confirms Config.Segment.Codec is actually applied at
+// segment-write time, and that reopening a segment detects the codec a
+// store file was written with from its header byte rather than trusting
+// whatever codec the caller's Config asks for now.
+func TestSegmentCodec(t *testing.T) {
+	dir, err := os.MkdirTemp("", "segment-codec-test")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	want := &api.Record{Value: []byte("hello world")}
+
+	c := Config{}
+	c.Segment.MaxStoreBytes = 1024
+	c.Segment.MaxIndexBytes = 1024
+	c.Segment.Codec = GzipCodec{}
+
+	s, err := newSegment(dir, 0, c)
+	require.NoError(t, err)
+	off, err := s.Append(want)
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+
+	// Reopen under a different Config.Segment.Codec: the segment's store
+	// should still read back correctly, because it detects gzip from the
+	// store file's header byte rather than trusting the new Config.
+	c.Segment.Codec = ZstdCodec{}
+	s, err = newSegment(dir, 0, c)
+	require.NoError(t, err)
+	got, err := s.Read(off)
+	require.NoError(t, err)
+	require.Equal(t, want.Value, got.Value)
+}
@@ -0,0 +1,21 @@
+package log
+
+// Config configures a Log and the segments it creates.
+type Config struct {
+	Segment struct {
+		// MaxStoreBytes and MaxIndexBytes bound how large a segment's
+		// store and index files may grow before Log rolls a new segment.
+		MaxStoreBytes uint64
+		MaxIndexBytes uint64
+		// InitialOffset is the offset the log's first segment starts
+		// numbering from. internal/distributed sets this to 1 so its
+		// Raft log store's offsets line up with Raft's own log indexes,
+		// which start at 1.
+		InitialOffset uint64
+		// Codec compresses each record's bytes before a segment's store
+		// writes them, and decompresses them back out on read. It
+		// defaults to NoneCodec, the log's original raw encoding, when
+		// left unset.
+		Codec Codec
+	}
+}
@@ -1,7 +1,8 @@
 package log
 
 import (
-	"fmt"
+	"bytes"
+	"context"
 	"io"
 	"os"
 	"path"
@@ -9,6 +10,11 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
 
 	api "github.com/lipandr/go-microsrv-distib-log/api/v1"
 )
@@ -19,6 +25,7 @@ type Log struct {
 	Config        Config
 	activeSegment *segment
 	segments      []*segment
+	otelReg       metric.Registration
 }
 
 // NewLog creates a new log instance. We first set defaults for the configs the caller didn't specify,
@@ -34,7 +41,15 @@ func NewLog(dir string, c Config) (*Log, error) {
 		Dir:    dir,
 		Config: c,
 	}
-	return l, l.setup()
+	if err := l.setup(); err != nil {
+		return nil, err
+	}
+	reg, err := l.observeActiveSegments()
+	if err != nil {
+		return nil, err
+	}
+	l.otelReg = reg
+	return l, nil
 }
 
 // When the log starts, it's responsible for setting itself up for the segments that already exist on the disk or,
@@ -74,15 +89,28 @@ func (l *Log) setup() error {
 }
 
 // Append appends a record to the log. We append the record to the active segment, and if the active segment is full,
-// then we create a new segment.
-func (l *Log) Append(record *api.Record) (uint64, error) {
+// then we create a new segment. The span it starts is a child of ctx, so
+// callers that already have a request-scoped span (the gRPC server's
+// unary interceptor, notably) get one trace across the whole call.
+func (l *Log) Append(ctx context.Context, record *api.Record) (uint64, error) {
+	ctx, span := tracer.Start(ctx, "log.Append")
+	defer span.End()
+	start := time.Now()
+	defer func() {
+		appendLatency.Record(ctx, float64(time.Since(start).Microseconds())/1000)
+	}()
+
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	off, err := l.activeSegment.Append(record)
 	if err != nil {
+		span.RecordError(err)
 		return 0, err
 	}
+	recordsAppended.Add(ctx, 1)
+	bytesWritten.Add(ctx, int64(len(record.Value)))
 	if l.activeSegment.IsMaxed() {
+		segmentRolls.Add(ctx, 1)
 		err = l.newSegment(off + 1)
 	}
 	return off, err
@@ -104,7 +132,23 @@ func (l *Log) newSegment(off uint64) error {
 // that contains the given record. Once we know the segment that contains the record,
 // we get the index entry from segment's index, and we read the data out of
 // the segment's store file and return tha data.
-func (l *Log) Read(off uint64) (*api.Record, error) {
+//
+// If off falls outside what the log currently holds, Read returns an
+// api.ErrOffsetOutOfRange carrying the log's current bounds so callers
+// (notably the gRPC ConsumeStream handler) can decide whether to
+// reposition or block-and-follow.
+//
+// The span it starts is a child of ctx, so a request-scoped span (the
+// gRPC server's unary interceptor, notably) parents this one.
+func (l *Log) Read(ctx context.Context, off uint64) (*api.Record, error) {
+	ctx, span := tracer.Start(ctx, "log.Read",
+		trace.WithAttributes(attribute.Int64("offset", int64(off))))
+	defer span.End()
+	start := time.Now()
+	defer func() {
+		readLatency.Record(ctx, float64(time.Since(start).Microseconds())/1000)
+	}()
+
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	var s *segment
@@ -115,13 +159,29 @@ func (l *Log) Read(off uint64) (*api.Record, error) {
 		}
 	}
 	if s == nil {
-		return nil, fmt.Errorf("offset out of range: %d", off)
+		lowest := l.segments[0].baseOffset
+		highest := l.segments[len(l.segments)-1].nextOffset
+		if highest > 0 {
+			highest--
+		}
+		err := api.ErrOffsetOutOfRange{Offset: off, Lowest: lowest, Highest: highest}
+		span.RecordError(err)
+		return nil, err
+	}
+	record, err := s.Read(off)
+	if err != nil {
+		span.RecordError(err)
 	}
-	return s.Read(off)
+	return record, err
 }
 
 // Close iterates over the segments and closes them.
 func (l *Log) Close() error {
+	if l.otelReg != nil {
+		if err := l.otelReg.Unregister(); err != nil {
+			return err
+		}
+	}
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	for _, segment := range l.segments {
@@ -132,12 +192,24 @@ func (l *Log) Close() error {
 	return nil
 }
 
-// Remove closes the log and removes the store and index files.
+// Remove removes every segment's store and index files, leaving l.Dir
+// itself in place so a subsequent setup (see Reset) can read it back.
 func (l *Log) Remove() error {
-	if err := l.Close(); err != nil {
-		return err
+	if l.otelReg != nil {
+		if err := l.otelReg.Unregister(); err != nil {
+			return err
+		}
 	}
-	return os.Remove(l.Dir)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, segment := range l.segments {
+		if err := segment.Remove(); err != nil {
+			return err
+		}
+	}
+	l.segments = nil
+	l.activeSegment = nil
+	return nil
 }
 
 // Reset removes the log and creates a new one to replace it.
@@ -188,14 +260,32 @@ func (l *Log) Truncate(lowest uint64) error {
 	return nil
 }
 
-// Reader returns an io.Reader that reads the whole log.
-// We'll need this capability when we implement coordinate consensus and need to support snapshots and restoring a log.
+// Reader returns an io.Reader that reads the whole log: each segment's
+// raw store bytes (its codec header and every frame, undecoded),
+// prefixed with that segment's byte length. The length prefix lets a
+// consumer that needs to tell segments apart - distributed.fsm's Raft
+// snapshot Restore, notably - know where one segment's bytes end and
+// the next one's header begins, without the store interpreting any of
+// it here.
 func (l *Log) Reader() io.Reader {
 	l.mu.RLock()
 	defer l.mu.RUnlock()
 	readers := make([]io.Reader, len(l.segments))
 	for i, segment := range l.segments {
-		readers[i] = &originReader{segment.store, 0}
+		size := make([]byte, lenWidth)
+		enc.PutUint64(size, segment.store.size)
+		// The *originReader is wrapped in a struct that exposes only
+		// Read: segment.store embeds *os.File, so an *originReader on its
+		// own promotes the file's WriteTo/ReadFrom methods too, and
+		// io.Copy prefers those over calling Read. That promoted WriteTo
+		// reads from the OS file's shared cursor rather than o.off, so
+		// once anything (even our own buffered Append) has moved that
+		// cursor past the start, a caller copying through this reader
+		// would silently get zero bytes instead of the segment's data.
+		readers[i] = io.MultiReader(
+			bytes.NewReader(size),
+			struct{ io.Reader }{&originReader{segment.store, 0}},
+		)
 	}
 	return io.MultiReader(readers...)
 }
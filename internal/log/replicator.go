@@ -0,0 +1,235 @@
+package log
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+
+	api "github.com/lipandr/go-microsrv-distib-log/api/v1"
+	"github.com/lipandr/go-microsrv-distib-log/internal/discovery"
+)
+
+// replicatorMinBackoff and replicatorMaxBackoff bound the delay Replicator
+// waits between retries against a peer it can't currently reach, so a
+// peer that's down or gone doesn't cost a tight retry loop.
+const (
+	replicatorMinBackoff = 100 * time.Millisecond
+	replicatorMaxBackoff = 10 * time.Second
+)
+
+var _ discovery.Handler = (*Replicator)(nil)
+
+// Replicator pulls records from other cluster members: on each peer join
+// it opens a ConsumeStream starting just past the local HighestOffset()
+// and appends what it receives, so a node that comes up empty catches up
+// without needing a snapshot.
+//
+// This is a simpler, async alternative to the Raft-replicated
+// distributed.Log: there's no consensus on write order, so it suits a
+// read-heavy follower but not a system that needs linearizable writes.
+type Replicator struct {
+	DialOptions []grpc.DialOption
+	LocalServer *Log
+
+	mu      sync.Mutex
+	servers map[string]chan struct{}
+	seen    map[string]uint64
+	closed  bool
+	close   chan struct{}
+}
+
+func (r *Replicator) init() {
+	if r.servers == nil {
+		r.servers = make(map[string]chan struct{})
+	}
+	if r.seen == nil {
+		r.seen = make(map[string]uint64)
+	}
+	if r.close == nil {
+		r.close = make(chan struct{})
+	}
+}
+
+// Join starts replicating from the server at addr, identified by name.
+// It's a no-op if we're already replicating from that server.
+func (r *Replicator) Join(name, addr string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.init()
+	if r.closed {
+		return nil
+	}
+	if _, ok := r.servers[name]; ok {
+		return nil
+	}
+	leave := make(chan struct{})
+	r.servers[name] = leave
+	go r.replicate(name, addr, leave)
+	return nil
+}
+
+func (r *Replicator) replicate(name, addr string, leave chan struct{}) {
+	backoff := replicatorMinBackoff
+	for {
+		cc, err := grpc.Dial(addr, r.DialOptions...)
+		if err != nil {
+			r.logError(err, "failed to dial", addr)
+			if !r.sleep(&backoff, leave) {
+				return
+			}
+			continue
+		}
+
+		if !r.consume(cc, name, addr, leave, &backoff) {
+			_ = cc.Close()
+			return
+		}
+		_ = cc.Close()
+	}
+}
+
+// consume streams records from cc starting just past our current highest
+// offset and appends each one we haven't seen before. It returns false
+// once the caller should stop retrying this peer altogether (we were
+// told to leave or close), and true once the stream has ended and it
+// has already waited out the backoff, so the caller should redial.
+func (r *Replicator) consume(cc *grpc.ClientConn, name, addr string, leave chan struct{}, backoff *time.Duration) bool {
+	client := api.NewLogClient(cc)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	offset, err := r.LocalServer.HighestOffset()
+	if err != nil {
+		r.logError(err, "failed to fetch highest offset", addr)
+		return r.sleep(backoff, leave)
+	}
+	// HighestOffset reports 0 for both an empty log and a one-record log;
+	// only step past it if a record is actually held there.
+	if _, err := r.LocalServer.Read(ctx, offset); err == nil {
+		offset++
+	}
+
+	stream, err := client.ConsumeStream(ctx, &api.ConsumeRequest{Offset: offset})
+	if err != nil {
+		r.logError(err, "failed to consume", addr)
+		return r.sleep(backoff, leave)
+	}
+
+	records := make(chan *api.Record)
+	errs := make(chan error, 1)
+	go func() {
+		for {
+			res, err := stream.Recv()
+			if err != nil {
+				errs <- err
+				return
+			}
+			// The select loop below can return via r.close or leave
+			// while this goroutine is attempting this send; without
+			// also selecting on ctx.Done() here, that send would block
+			// forever (the loop was the only reader of records) and
+			// this goroutine, along with the stream's receive buffer,
+			// would leak. consume's deferred cancel() closes ctx.Done()
+			// on every return path, so it doubles as this goroutine's
+			// own shutdown signal.
+			select {
+			case records <- res.Record:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-r.close:
+			return false
+		case <-leave:
+			return false
+		case err := <-errs:
+			r.logError(err, "replication stream ended", addr)
+			return r.sleep(backoff, leave)
+		case record := <-records:
+			if r.seenBefore(name, record) {
+				continue
+			}
+			if _, err := r.LocalServer.Append(ctx, record); err != nil {
+				r.logError(err, "failed to append", addr)
+			}
+			*backoff = replicatorMinBackoff
+		}
+	}
+}
+
+// seenBefore reports whether we've already appended the record at this
+// offset from this peer, and remembers the offset if not. Records are
+// keyed by (name, record.Offset) rather than by value: a record's own
+// offset in the peer's log is its identity, so two records that happen
+// to carry the same value are never mistaken for one that's already
+// been replicated. This is what keeps a node from looping a record back
+// to the peer it just replicated it from once that peer, in turn,
+// starts replicating from us, while bounding r.seen to one entry per
+// peer instead of one per record ever replicated.
+func (r *Replicator) seenBefore(name string, record *api.Record) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if highest, ok := r.seen[name]; ok && record.Offset <= highest {
+		return true
+	}
+	r.seen[name] = record.Offset
+	return false
+}
+
+func (r *Replicator) sleep(backoff *time.Duration, leave chan struct{}) bool {
+	timer := time.NewTimer(*backoff)
+	defer timer.Stop()
+	select {
+	case <-r.close:
+		return false
+	case <-leave:
+		return false
+	case <-timer.C:
+	}
+	*backoff *= 2
+	if *backoff > replicatorMaxBackoff {
+		*backoff = replicatorMaxBackoff
+	}
+	return true
+}
+
+// Leave stops replicating from the server with the given name.
+func (r *Replicator) Leave(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.init()
+	leave, ok := r.servers[name]
+	if !ok {
+		return nil
+	}
+	close(leave)
+	delete(r.servers, name)
+	delete(r.seen, name)
+	return nil
+}
+
+// Close stops replicating from every server and prevents Join from
+// starting any new ones.
+func (r *Replicator) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.init()
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	close(r.close)
+	return nil
+}
+
+func (r *Replicator) logError(err error, msg, addr string) {
+	log.Printf("[ERROR] log: replicator: %s: %v, addr=%s", msg, err, addr)
+}
@@ -0,0 +1,139 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	api "github.com/lipandr/go-microsrv-distib-log/api/v1"
+	"github.com/lipandr/go-microsrv-distib-log/internal/server"
+)
+
+// newTestReplicationServer stands up a real gRPC server, backed by a real
+// Log, that a Replicator can dial and consume from - as opposed to
+// newTestReplicator's peers, which nothing is listening on and so never
+// get far enough into consume for its streaming goroutine to matter.
+func newTestReplicationServer(t *testing.T) (addr string, source *Log) {
+	t.Helper()
+	l, err := NewLog(t.TempDir(), Config{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+
+	gsrv, err := server.NewGRPCServer(&server.Config{CommitLog: l})
+	require.NoError(t, err)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = gsrv.Serve(lis) }()
+	t.Cleanup(gsrv.Stop)
+
+	return lis.Addr().String(), l
+}
+
+// countGoroutines waits briefly for goroutine counts to settle, since a
+// goroutine that's about to exit cleanly doesn't do so instantaneously.
+func countGoroutines() int {
+	runtime.Gosched()
+	time.Sleep(10 * time.Millisecond)
+	return runtime.NumGoroutine()
+}
+
+// TestReplicatorLeaveStopsActiveConsume confirms that calling Leave while
+// consume's inner goroutine is blocked trying to send a record to the
+// (unbuffered) records channel doesn't leak that goroutine: it must
+// notice the shutdown and return instead of blocking forever once the
+// outer select loop has already exited via leave.
+func TestReplicatorLeaveStopsActiveConsume(t *testing.T) {
+	addr, source := newTestReplicationServer(t)
+
+	r := &Replicator{
+		DialOptions: []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())},
+		LocalServer: mustNewLog(t),
+	}
+
+	before := countGoroutines()
+
+	require.NoError(t, r.Join("source", addr))
+
+	// Keep the peer busy streaming records so consume's goroutine is
+	// actively trying to send, rather than idle waiting on Recv, when
+	// Leave is called.
+	for i := 0; i < 50; i++ {
+		_, err := source.Append(context.Background(), &api.Record{Value: []byte(fmt.Sprintf("record-%d", i))})
+		require.NoError(t, err)
+	}
+
+	require.Eventually(t, func() bool {
+		off, err := r.LocalServer.HighestOffset()
+		return err == nil && off > 0
+	}, time.Second, 10*time.Millisecond, "replicator never caught up to the source log")
+
+	require.NoError(t, r.Leave("source"))
+
+	requireGoroutinesSettle(t, before, "after Leave")
+}
+
+// TestReplicatorCloseStopsActiveConsume is TestReplicatorLeaveStopsActiveConsume's
+// counterpart for Close, the other path consume's select loop can exit
+// through while its Recv goroutine is blocked on a send.
+func TestReplicatorCloseStopsActiveConsume(t *testing.T) {
+	addr, source := newTestReplicationServer(t)
+
+	r := &Replicator{
+		DialOptions: []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())},
+		LocalServer: mustNewLog(t),
+	}
+
+	before := countGoroutines()
+
+	require.NoError(t, r.Join("source", addr))
+
+	for i := 0; i < 50; i++ {
+		_, err := source.Append(context.Background(), &api.Record{Value: []byte(fmt.Sprintf("record-%d", i))})
+		require.NoError(t, err)
+	}
+
+	require.Eventually(t, func() bool {
+		off, err := r.LocalServer.HighestOffset()
+		return err == nil && off > 0
+	}, time.Second, 10*time.Millisecond, "replicator never caught up to the source log")
+
+	require.NoError(t, r.Close())
+
+	requireGoroutinesSettle(t, before, "after Close")
+}
+
+func mustNewLog(t *testing.T) *Log {
+	t.Helper()
+	l, err := NewLog(t.TempDir(), Config{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+	return l
+}
+
+// requireGoroutinesSettle polls until the goroutine count drops back to (or
+// below) before, giving recently-stopped goroutines - the replicator's
+// consume goroutine among them - time to actually exit rather than merely
+// being signaled to. It fails the test with a full goroutine dump if that
+// never happens within the deadline.
+func requireGoroutinesSettle(t *testing.T, before int, when string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if countGoroutines() <= before {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	buf := make([]byte, 1<<20)
+	n := runtime.Stack(buf, true)
+	t.Logf("goroutine dump:\n%s", buf[:n])
+	require.LessOrEqual(t, countGoroutines(), before, "goroutine count never returned to baseline "+when)
+}
@@ -0,0 +1,104 @@
+package log
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	api "github.com/lipandr/go-microsrv-distib-log/api/v1"
+)
+
+// newTestReplicator returns a Replicator backed by a real, empty Log, so
+// a replication goroutine that manages to dial can call HighestOffset
+// without panicking on a nil LocalServer.
+func newTestReplicator(t *testing.T) *Replicator {
+	t.Helper()
+	l, err := NewLog(t.TempDir(), Config{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+	return &Replicator{
+		DialOptions: []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())},
+		LocalServer: l,
+	}
+}
+
+// TestReplicatorSeenBeforeDedupesByPeerAndOffset confirms seenBefore keys
+// on (name, record.Offset) rather than the record's value: two distinct
+// peers can replicate records with the same value without one peer's
+// record being mistaken for the other's, and replaying the same offset
+// from the same peer is recognized as already seen.
+func TestReplicatorSeenBeforeDedupesByPeerAndOffset(t *testing.T) {
+	r := &Replicator{}
+	r.init()
+
+	require.False(t, r.seenBefore("peer-a", &api.Record{Value: []byte("x"), Offset: 0}))
+	require.True(t, r.seenBefore("peer-a", &api.Record{Value: []byte("x"), Offset: 0}))
+
+	// A higher offset from the same peer is new, even with the same value.
+	require.False(t, r.seenBefore("peer-a", &api.Record{Value: []byte("x"), Offset: 1}))
+
+	// A different peer's offset 0 is unrelated to peer-a's bookkeeping,
+	// even though it carries the same value peer-a already saw.
+	require.False(t, r.seenBefore("peer-b", &api.Record{Value: []byte("x"), Offset: 0}))
+
+	// An offset replayed from a peer at or below its high-water mark (here
+	// the mark itself, with a different value) is recognized as already
+	// seen.
+	require.True(t, r.seenBefore("peer-a", &api.Record{Value: []byte("y"), Offset: 1}))
+}
+
+// TestReplicatorLeaveForgetsSeenOffsets confirms Leave clears a peer's
+// dedup state along with its replication goroutine, so if the same name
+// rejoins later (e.g. after a restart) its offsets are tracked fresh
+// rather than compared against a stale high-water mark.
+func TestReplicatorLeaveForgetsSeenOffsets(t *testing.T) {
+	r := newTestReplicator(t)
+
+	require.NoError(t, r.Join("peer-a", "127.0.0.1:1"))
+	require.False(t, r.seenBefore("peer-a", &api.Record{Offset: 5}))
+
+	require.NoError(t, r.Leave("peer-a"))
+	r.mu.Lock()
+	_, ok := r.seen["peer-a"]
+	r.mu.Unlock()
+	require.False(t, ok)
+
+	require.False(t, r.seenBefore("peer-a", &api.Record{Offset: 0}))
+}
+
+// TestReplicatorJoinIsIdempotent confirms joining the same peer name
+// twice doesn't start a second replication goroutine or clobber the
+// first one's leave channel.
+func TestReplicatorJoinIsIdempotent(t *testing.T) {
+	r := newTestReplicator(t)
+
+	require.NoError(t, r.Join("peer-a", "127.0.0.1:1"))
+	r.mu.Lock()
+	leave := r.servers["peer-a"]
+	r.mu.Unlock()
+	require.NotNil(t, leave)
+
+	require.NoError(t, r.Join("peer-a", "127.0.0.1:1"))
+	r.mu.Lock()
+	sameLeave := r.servers["peer-a"]
+	r.mu.Unlock()
+	require.Equal(t, leave, sameLeave)
+
+	require.NoError(t, r.Leave("peer-a"))
+}
+
+// TestReplicatorJoinNoopAfterClose confirms Join does nothing once the
+// Replicator has been closed, so a node that's shutting down doesn't
+// spin up new replication goroutines it'll never clean up.
+func TestReplicatorJoinNoopAfterClose(t *testing.T) {
+	r := newTestReplicator(t)
+	require.NoError(t, r.Close())
+	require.NoError(t, r.Join("peer-a", "127.0.0.1:1"))
+
+	r.mu.Lock()
+	_, ok := r.servers["peer-a"]
+	r.mu.Unlock()
+	require.False(t, ok)
+}
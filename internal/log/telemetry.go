@@ -0,0 +1,86 @@
+package log
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const instrumentationName = "github.com/lipandr/go-microsrv-distib-log/internal/log"
+
+var (
+	tracer = otel.Tracer(instrumentationName)
+	meter  = otel.Meter(instrumentationName)
+
+	recordsAppended     metric.Int64Counter
+	bytesWritten        metric.Int64Counter
+	segmentRolls        metric.Int64Counter
+	appendLatency       metric.Float64Histogram
+	readLatency         metric.Float64Histogram
+	activeSegmentsGauge metric.Int64ObservableGauge
+)
+
+// init registers the log package's instruments once against whatever
+// MeterProvider/TracerProvider internal/telemetry installs. If nothing
+// installs one, otel's no-op implementations take over and these calls
+// are free.
+func init() {
+	var err error
+	recordsAppended, err = meter.Int64Counter(
+		"log.records_appended",
+		metric.WithDescription("Records appended to the log"),
+	)
+	otel.Handle(err)
+
+	bytesWritten, err = meter.Int64Counter(
+		"log.bytes_written",
+		metric.WithDescription("Bytes written to segment stores"),
+		metric.WithUnit("By"),
+	)
+	otel.Handle(err)
+
+	segmentRolls, err = meter.Int64Counter(
+		"log.segment_rolls",
+		metric.WithDescription("Times the active segment rolled over to a new one"),
+	)
+	otel.Handle(err)
+
+	appendLatency, err = meter.Float64Histogram(
+		"log.append.latency",
+		metric.WithDescription("Append call latency"),
+		metric.WithUnit("ms"),
+	)
+	otel.Handle(err)
+
+	readLatency, err = meter.Float64Histogram(
+		"log.read.latency",
+		metric.WithDescription("Read call latency"),
+		metric.WithUnit("ms"),
+	)
+	otel.Handle(err)
+
+	activeSegmentsGauge, err = meter.Int64ObservableGauge(
+		"log.active_segments",
+		metric.WithDescription("Number of segments currently held open by a log"),
+	)
+	otel.Handle(err)
+}
+
+// observeActiveSegments registers a callback that reports l's current
+// segment count against activeSegmentsGauge, tagged with l.Dir so
+// multiple logs in the same process (e.g. the commit log and the Raft
+// log store in internal/distributed) are distinguishable. The returned
+// registration must be unregistered when l is closed.
+func (l *Log) observeActiveSegments() (metric.Registration, error) {
+	return meter.RegisterCallback(
+		func(_ context.Context, o metric.Observer) error {
+			l.mu.RLock()
+			defer l.mu.RUnlock()
+			o.ObserveInt64(activeSegmentsGauge, int64(len(l.segments)), metric.WithAttributes(attribute.String("dir", l.Dir)))
+			return nil
+		},
+		activeSegmentsGauge,
+	)
+}
@@ -0,0 +1,30 @@
+package log
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestObserveActiveSegmentsLifecycle confirms a Log registers its gauge
+// callback on creation and unregisters it on Close, and that opening and
+// closing several logs in the same process (each against the global
+// meter instruments registered once in init) never errors - which would
+// otherwise indicate a leaked or duplicate registration.
+func TestObserveActiveSegmentsLifecycle(t *testing.T) {
+	for i := 0; i < 3; i++ {
+		l, err := NewLog(t.TempDir(), Config{})
+		require.NoError(t, err)
+		require.NotNil(t, l.otelReg)
+		require.NoError(t, l.Close())
+	}
+}
+
+// TestRemoveUnregistersGauge confirms Remove, which like Close
+// unregisters the gauge callback before removing the segments, completes
+// without error. Whether the callback stops being invoked is not checked.
+func TestRemoveUnregistersGauge(t *testing.T) {
+	l, err := NewLog(t.TempDir(), Config{})
+	require.NoError(t, err)
+	require.NoError(t, l.Remove())
+}
@@ -0,0 +1,207 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	api "github.com/lipandr/go-microsrv-distib-log/api/v1"
+	"github.com/lipandr/go-microsrv-distib-log/internal/auth"
+)
+
+// objectWildcard is the resource every request is authorized against: the
+// log doesn't (yet) have per-topic or per-partition resources, so every
+// Produce/Consume checks the same object.
+const objectWildcard = "*"
+
+const (
+	produceAction = "produce"
+	consumeAction = "consume"
+)
+
+// tailPollInterval is how often ConsumeStream retries once it has caught
+// up to the log's highest offset and is waiting for new records.
+const tailPollInterval = 100 * time.Millisecond
+
+// CommitLog is the subset of log.Log the gRPC server depends on, so it can
+// be swapped for a replicated implementation (see internal/distributed)
+// without this package knowing the difference. Append and Read take the
+// request's context so a span they start (see internal/log's tracer)
+// parents into the one UnaryServerInterceptor opens for the call.
+type CommitLog interface {
+	Append(context.Context, *api.Record) (uint64, error)
+	Read(context.Context, uint64) (*api.Record, error)
+	LowestOffset() (uint64, error)
+	HighestOffset() (uint64, error)
+}
+
+// GetServerer reports the current cluster membership. *distributed.Log
+// implements it; a single-node CommitLog can leave it nil and GetServers
+// will return Unimplemented.
+type GetServerer interface {
+	GetServers() ([]*api.Server, error)
+}
+
+// Config carries the dependencies the gRPC server needs to handle requests.
+type Config struct {
+	CommitLog   CommitLog
+	GetServerer GetServerer
+	Authorizer  *auth.Authorizer
+
+	// TLSConfig, if set, is used both to present this server's certificate
+	// and, via its ClientCAs pool, to verify client certificates for
+	// mutual TLS. The client certificate's Common Name becomes the
+	// subject Authorizer checks requests against.
+	TLSConfig *tls.Config
+}
+
+var _ api.LogServer = (*grpcServer)(nil)
+
+type grpcServer struct {
+	api.UnimplementedLogServer
+	*Config
+}
+
+func newGRPCServer(config *Config) (*grpcServer, error) {
+	return &grpcServer{Config: config}, nil
+}
+
+// NewGRPCServer builds a *grpc.Server with the Log service registered.
+// If config.TLSConfig is set, it's wired up as the server's transport
+// credentials before any caller-supplied opts (TLS creds, interceptors,
+// and the like) the way internal/cmd wires up the process.
+func NewGRPCServer(config *Config, opts ...grpc.ServerOption) (*grpc.Server, error) {
+	opts = append([]grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(StreamServerInterceptor()),
+	}, opts...)
+	if config.TLSConfig != nil {
+		opts = append([]grpc.ServerOption{grpc.Creds(credentials.NewTLS(config.TLSConfig))}, opts...)
+	}
+	gsrv := grpc.NewServer(opts...)
+	srv, err := newGRPCServer(config)
+	if err != nil {
+		return nil, err
+	}
+	api.RegisterLogServer(gsrv, srv)
+	return gsrv, nil
+}
+
+// subject returns the Common Name from the caller's client certificate,
+// or "" if the call didn't come in over mutual TLS.
+func subject(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	return tlsInfo.State.VerifiedChains[0][0].Subject.CommonName
+}
+
+// authorize checks the caller against Authorizer, skipping the check
+// entirely when no Authorizer is configured (a single-node server run
+// without TLS, e.g. in tests, behaves as it did before auth existed).
+func (s *grpcServer) authorize(ctx context.Context, action string) error {
+	if s.Authorizer == nil {
+		return nil
+	}
+	return s.Authorizer.Authorize(subject(ctx), objectWildcard, action)
+}
+
+func (s *grpcServer) Produce(ctx context.Context, req *api.ProduceRequest) (*api.ProduceResponse, error) {
+	if err := s.authorize(ctx, produceAction); err != nil {
+		return nil, err
+	}
+	off, err := s.CommitLog.Append(ctx, req.Record)
+	if err != nil {
+		return nil, err
+	}
+	return &api.ProduceResponse{Offset: off}, nil
+}
+
+func (s *grpcServer) Consume(ctx context.Context, req *api.ConsumeRequest) (*api.ConsumeResponse, error) {
+	if err := s.authorize(ctx, consumeAction); err != nil {
+		return nil, err
+	}
+	record, err := s.CommitLog.Read(ctx, req.Offset)
+	if err != nil {
+		return nil, err
+	}
+	return &api.ConsumeResponse{Record: record}, nil
+}
+
+func (s *grpcServer) GetServers(_ context.Context, _ *api.GetServersRequest) (*api.GetServersResponse, error) {
+	if s.GetServerer == nil {
+		return nil, status.Error(codes.Unimplemented, "no membership configured")
+	}
+	servers, err := s.GetServerer.GetServers()
+	if err != nil {
+		return nil, err
+	}
+	return &api.GetServersResponse{Servers: servers}, nil
+}
+
+// ProduceStream allows a client to append a batch of records over a single
+// bidirectional stream instead of one round trip per record.
+func (s *grpcServer) ProduceStream(stream api.Log_ProduceStreamServer) error {
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		res, err := s.Produce(stream.Context(), req)
+		if err != nil {
+			return err
+		}
+		if err = stream.Send(res); err != nil {
+			return err
+		}
+	}
+}
+
+// ConsumeStream tails the log from req.Offset. Once it catches up to the
+// log's current highest offset it doesn't return EOF like a one-shot
+// Consume would: it polls until a new record is appended or the client
+// disconnects, so consumers can follow the log as it grows.
+func (s *grpcServer) ConsumeStream(req *api.ConsumeRequest, stream api.Log_ConsumeStreamServer) error {
+	off := req.Offset
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		default:
+			res, err := s.Consume(stream.Context(), &api.ConsumeRequest{Offset: off})
+			switch err := err.(type) {
+			case nil:
+			case api.ErrOffsetOutOfRange:
+				// Out of range but not below the lowest offset means off
+				// hasn't been produced yet, including on an empty log
+				// (whose HighestOffset reports 0, same as a one-record log).
+				if lowest, lErr := s.CommitLog.LowestOffset(); lErr == nil && off >= lowest {
+					time.Sleep(tailPollInterval)
+					continue
+				}
+				return err
+			default:
+				return err
+			}
+			if err = stream.Send(res); err != nil {
+				return err
+			}
+			off++
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	api "github.com/lipandr/go-microsrv-distib-log/api/v1"
+)
+
+// newTestServerClient starts an insecure, unauthenticated gRPC server
+// backed by the given CommitLog and returns a client dialed against it.
+// Streaming behavior doesn't depend on TLS/auth, so this skips both
+// (TestMutualTLSAuthorization already covers that wiring).
+func newTestServerClient(t *testing.T, commitLog CommitLog) api.LogClient {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	gsrv, err := NewGRPCServer(&Config{CommitLog: commitLog})
+	require.NoError(t, err)
+	go func() { _ = gsrv.Serve(lis) }()
+	t.Cleanup(gsrv.Stop)
+
+	cc, err := grpc.NewClient(
+		lis.Addr().String(),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = cc.Close() })
+
+	return api.NewLogClient(cc)
+}
+
+// TestProduceStreamBatch confirms a client can send a batch of records
+// over one ProduceStream call and end it cleanly with CloseSend, rather
+// than the server treating the client's io.EOF as a failed stream.
+func TestProduceStreamBatch(t *testing.T) {
+	commitLog := &fakeCommitLog{}
+	client := newTestServerClient(t, commitLog)
+
+	stream, err := client.ProduceStream(context.Background())
+	require.NoError(t, err)
+
+	values := []string{"one", "two", "three"}
+	for _, value := range values {
+		require.NoError(t, stream.Send(&api.ProduceRequest{
+			Record: &api.Record{Value: []byte(value)},
+		}))
+		res, err := stream.Recv()
+		require.NoError(t, err)
+		require.Less(t, res.Offset, uint64(len(values)))
+	}
+
+	require.NoError(t, stream.CloseSend())
+	_, err = stream.Recv()
+	require.Equal(t, io.EOF, err)
+
+	commitLog.mu.Lock()
+	defer commitLog.mu.Unlock()
+	require.Len(t, commitLog.records, len(values))
+	for i, value := range values {
+		require.Equal(t, value, string(commitLog.records[i].Value))
+	}
+}
+
+// TestConsumeStreamBlocksThenDeliversNewRecord confirms ConsumeStream
+// catches up to the log's current records, then blocks - rather than
+// ending the stream - once it's caught up, and delivers a record
+// appended after it started tailing.
+func TestConsumeStreamBlocksThenDeliversNewRecord(t *testing.T) {
+	commitLog := &fakeCommitLog{}
+	_, err := commitLog.Append(context.Background(), &api.Record{Value: []byte("existing")})
+	require.NoError(t, err)
+
+	client := newTestServerClient(t, commitLog)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := client.ConsumeStream(ctx, &api.ConsumeRequest{Offset: 0})
+	require.NoError(t, err)
+
+	res, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "existing", string(res.Record.Value))
+
+	// The stream has caught up to the log's highest offset; it should
+	// now be polling rather than ending. Recv on a separate goroutine so
+	// a regression that returns early doesn't hang the test forever.
+	recvDone := make(chan struct{})
+	var recvRes *api.ConsumeResponse
+	var recvErr error
+	go func() {
+		recvRes, recvErr = stream.Recv()
+		close(recvDone)
+	}()
+
+	select {
+	case <-recvDone:
+		t.Fatalf("ConsumeStream returned before a new record was appended: res=%v err=%v", recvRes, recvErr)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	_, err = commitLog.Append(context.Background(), &api.Record{Value: []byte("appended later")})
+	require.NoError(t, err)
+
+	select {
+	case <-recvDone:
+	case <-time.After(3 * time.Second):
+		t.Fatal("ConsumeStream never delivered the newly appended record")
+	}
+	require.NoError(t, recvErr)
+	require.Equal(t, "appended later", string(recvRes.Record.Value))
+}
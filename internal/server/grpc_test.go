@@ -0,0 +1,114 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+
+	api "github.com/lipandr/go-microsrv-distib-log/api/v1"
+	"github.com/lipandr/go-microsrv-distib-log/internal/auth"
+)
+
+// fakeCommitLog is the simplest CommitLog that satisfies the interface,
+// so this test can exercise the gRPC server's TLS and authorization
+// wiring without standing up a real internal/log.Log on disk.
+type fakeCommitLog struct {
+	mu      sync.Mutex
+	records []*api.Record
+}
+
+func (f *fakeCommitLog) Append(_ context.Context, record *api.Record) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	record.Offset = uint64(len(f.records))
+	f.records = append(f.records, record)
+	return record.Offset, nil
+}
+
+func (f *fakeCommitLog) Read(_ context.Context, offset uint64) (*api.Record, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if offset >= uint64(len(f.records)) {
+		return nil, api.ErrOffsetOutOfRange{Offset: offset}
+	}
+	return f.records[offset], nil
+}
+
+func (f *fakeCommitLog) LowestOffset() (uint64, error) { return 0, nil }
+
+func (f *fakeCommitLog) HighestOffset() (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.records) == 0 {
+		return 0, nil
+	}
+	return uint64(len(f.records)) - 1, nil
+}
+
+// TestMutualTLSAuthorization dials the gRPC server over mutual TLS as two
+// different client identities and checks that grpcServer.authorize maps
+// the Authorizer's decision onto the call: "root" may produce, "nobody"
+// may not.
+func TestMutualTLSAuthorization(t *testing.T) {
+	ca := newTestCA(t)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := lis.Addr().(*net.TCPAddr)
+
+	serverCert := ca.issue(t, "server", addr.IP)
+	serverTLS := &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientCAs:    ca.pool(),
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+
+	authorizer, err := auth.New("testdata/model.conf", "testdata/policy.csv")
+	require.NoError(t, err)
+
+	gsrv, err := NewGRPCServer(&Config{
+		CommitLog:  &fakeCommitLog{},
+		Authorizer: authorizer,
+		TLSConfig:  serverTLS,
+	})
+	require.NoError(t, err)
+	go func() { _ = gsrv.Serve(lis) }()
+	defer gsrv.Stop()
+
+	dial := func(cn string) api.LogClient {
+		clientCert := ca.issue(t, cn, nil)
+		clientTLS := &tls.Config{
+			Certificates: []tls.Certificate{clientCert},
+			RootCAs:      ca.pool(),
+			ServerName:   "server",
+		}
+		cc, err := grpc.NewClient(
+			addr.String(),
+			grpc.WithTransportCredentials(credentials.NewTLS(clientTLS)),
+		)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = cc.Close() })
+		return api.NewLogClient(cc)
+	}
+
+	root := dial("root")
+	_, err = root.Produce(context.Background(), &api.ProduceRequest{
+		Record: &api.Record{Value: []byte("hello")},
+	})
+	require.NoError(t, err)
+
+	nobody := dial("nobody")
+	_, err = nobody.Produce(context.Background(), &api.ProduceRequest{
+		Record: &api.Record{Value: []byte("hello")},
+	})
+	require.Error(t, err)
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+}
@@ -5,27 +5,66 @@ import (
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type HttpServer struct {
-	Log *Log
+	Log    *Log
+	Config *Config
 }
 
-func New(addr string) *http.Server {
-	srv := NewHTTPServer()
+// New builds the JSON HTTP server. If config.TLSConfig is set, the
+// returned *http.Server must be run with ListenAndServeTLS so it
+// presents this server's certificate and, via ClientCAs, verifies
+// client certificates for mutual TLS.
+func New(addr string, config *Config) *http.Server {
+	srv := NewHTTPServer(config)
 	r := mux.NewRouter()
 	r.HandleFunc("/", srv.handleProduce).Methods(http.MethodPost)
 	r.HandleFunc("/", srv.handleConsume).Methods(http.MethodGet)
-	return &http.Server{
+	httpSrv := &http.Server{
 		Addr:    addr,
-		Handler: r,
+		Handler: TelemetryMiddleware(r),
 	}
+	if config != nil {
+		httpSrv.TLSConfig = config.TLSConfig
+	}
+	return httpSrv
 }
 
-func NewHTTPServer() *HttpServer {
+func NewHTTPServer(config *Config) *HttpServer {
 	return &HttpServer{
-		Log: NewLog(),
+		Log:    NewLog(),
+		Config: config,
+	}
+}
+
+// authorize checks the caller's client certificate CN against Config's
+// Authorizer, the same way the gRPC server does. It's a no-op when no
+// Authorizer is configured.
+func (s *HttpServer) authorize(r *http.Request, action string) error {
+	if s.Config == nil || s.Config.Authorizer == nil {
+		return nil
+	}
+	var cn string
+	if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+		cn = r.TLS.PeerCertificates[0].Subject.CommonName
+	}
+	return s.Config.Authorizer.Authorize(cn, objectWildcard, action)
+}
+
+// authorizeStatus maps an authorize error to the HTTP status it should
+// produce: codes.PermissionDenied (the caller really is disallowed)
+// becomes 403, but codes.Internal (the enforcer itself failed) and
+// anything else become 500, so an enforcer outage isn't reported to the
+// caller as "you don't have permission" when it's really "we couldn't
+// tell".
+func authorizeStatus(err error) int {
+	if status.Code(err) == codes.PermissionDenied {
+		return http.StatusForbidden
 	}
+	return http.StatusInternalServerError
 }
 
 type ProduceRequest struct {
@@ -45,6 +84,10 @@ type ConsumeResponse struct {
 }
 
 func (s *HttpServer) handleProduce(w http.ResponseWriter, r *http.Request) {
+	if err := s.authorize(r, produceAction); err != nil {
+		http.Error(w, err.Error(), authorizeStatus(err))
+		return
+	}
 	var req ProduceRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
@@ -64,6 +107,10 @@ func (s *HttpServer) handleProduce(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *HttpServer) handleConsume(w http.ResponseWriter, r *http.Request) {
+	if err := s.authorize(r, consumeAction); err != nil {
+		http.Error(w, err.Error(), authorizeStatus(err))
+		return
+	}
 	var req ConsumeRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Log is the JSON HTTP server's own in-memory commit log: a simple
+// append-only slice, predating (and independent of) the gRPC API's
+// internal/log.Log. HttpServer talks to this type, not internal/log.
+type Log struct {
+	mu      sync.Mutex
+	records []Record
+}
+
+func NewLog() *Log {
+	return &Log{}
+}
+
+// Record is the JSON shape ProduceRequest/ConsumeResponse read and
+// write over HTTP.
+type Record struct {
+	Value  []byte `json:"value"`
+	Offset uint64 `json:"offset"`
+}
+
+// ErrOffsetNotFound is returned when a client asks to consume an offset
+// this log has never had a record at.
+var ErrOffsetNotFound = fmt.Errorf("offset not found")
+
+// Append appends record, assigning it the next offset.
+func (c *Log) Append(record Record) (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	record.Offset = uint64(len(c.records))
+	c.records = append(c.records, record)
+	return record.Offset, nil
+}
+
+// Read returns the record at the given offset.
+func (c *Log) Read(offset uint64) (Record, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if offset >= uint64(len(c.records)) {
+		return Record{}, ErrOffsetNotFound
+	}
+	return c.records[offset], nil
+}
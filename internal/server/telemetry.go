@@ -0,0 +1,103 @@
+package server
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+const telemetryInstrumentationName = "github.com/lipandr/go-microsrv-distib-log/internal/server"
+
+var tracer = otel.Tracer(telemetryInstrumentationName)
+
+// TelemetryMiddleware wraps an http.Handler with a span per request,
+// tagged with the method, path, and the response status code the
+// handler actually wrote.
+func TelemetryMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "http."+r.Method)
+		defer span.End()
+		span.SetAttributes(
+			attribute.String("http.method", r.Method),
+			attribute.String("http.path", r.URL.Path),
+		)
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		span.SetAttributes(attribute.Int("http.status_code", rec.status))
+		if rec.status >= http.StatusBadRequest {
+			span.SetStatus(otelcodes.Error, http.StatusText(rec.status))
+		}
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// UnaryServerInterceptor starts a span per unary gRPC call and records
+// its resulting status code, so NewGRPCServer callers can chain it in
+// alongside whatever else they pass as grpc.ServerOption.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		ctx, span := tracer.Start(ctx, info.FullMethod)
+		defer span.End()
+		resp, err := handler(ctx, req)
+		recordGRPCStatus(span, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor is UnaryServerInterceptor's counterpart for
+// the streaming RPCs (ProduceStream, ConsumeStream).
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		ctx, span := tracer.Start(ss.Context(), info.FullMethod)
+		defer span.End()
+		err := handler(srv, &tracingServerStream{ServerStream: ss, ctx: ctx})
+		recordGRPCStatus(span, err)
+		return err
+	}
+}
+
+// tracingServerStream overrides grpc.ServerStream.Context so handlers
+// see the span-carrying context StreamServerInterceptor created.
+type tracingServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *tracingServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func recordGRPCStatus(span trace.Span, err error) {
+	st, _ := grpcstatus.FromError(err)
+	span.SetAttributes(attribute.String("rpc.grpc.status_code", st.Code().String()))
+	if err != nil {
+		span.SetStatus(otelcodes.Error, err.Error())
+	}
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TestTelemetryMiddlewareRecordsHandlerStatus confirms the middleware
+// calls through to next and that statusRecorder captures whatever
+// status the handler actually wrote, rather than always reporting 200.
+func TestTelemetryMiddlewareRecordsHandlerStatus(t *testing.T) {
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/records", nil)
+	rec := httptest.NewRecorder()
+	TelemetryMiddleware(next).ServeHTTP(rec, req)
+
+	require.True(t, called)
+	require.Equal(t, http.StatusTeapot, rec.Code)
+}
+
+// TestTelemetryMiddlewareDefaultsToOK confirms a handler that never
+// calls WriteHeader is recorded as the implicit 200 net/http itself
+// would report, not as some other default.
+func TestTelemetryMiddlewareDefaultsToOK(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/records", nil)
+	rec := httptest.NewRecorder()
+	TelemetryMiddleware(next).ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+}
+
+// TestUnaryServerInterceptorPropagatesResponse confirms the interceptor
+// returns the handler's response and error untouched.
+func TestUnaryServerInterceptorPropagatesResponse(t *testing.T) {
+	interceptor := UnaryServerInterceptor()
+
+	resp, err := interceptor(context.Background(), "req", &grpc.UnaryServerInfo{FullMethod: "/test/Method"},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return "resp", nil
+		})
+	require.NoError(t, err)
+	require.Equal(t, "resp", resp)
+
+	wantErr := status.Error(codes.PermissionDenied, "nope")
+	_, err = interceptor(context.Background(), "req", &grpc.UnaryServerInfo{FullMethod: "/test/Method"},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, wantErr
+		})
+	require.Equal(t, wantErr, err)
+}
+
+// fakeServerStream is the minimal grpc.ServerStream needed to exercise
+// StreamServerInterceptor's context override.
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeServerStream) Context() context.Context { return s.ctx }
+
+// TestStreamServerInterceptorOverridesContext confirms the handler sees
+// a context descending from ss's original (so request-scoped values
+// still flow through) but distinct from it (so the interceptor's span
+// is actually in scope), and that the handler's error is propagated.
+func TestStreamServerInterceptorOverridesContext(t *testing.T) {
+	interceptor := StreamServerInterceptor()
+
+	type ctxKey struct{}
+	outerCtx := context.WithValue(context.Background(), ctxKey{}, "outer")
+	ss := &fakeServerStream{ctx: outerCtx}
+
+	var sawCtx context.Context
+	err := interceptor(nil, ss, &grpc.StreamServerInfo{FullMethod: "/test/Stream"},
+		func(srv interface{}, stream grpc.ServerStream) error {
+			sawCtx = stream.Context()
+			return errors.New("stream failed")
+		})
+
+	require.NotEqual(t, outerCtx, sawCtx, "handler should see the interceptor's own context, not ss's original")
+	require.Equal(t, "outer", sawCtx.Value(ctxKey{}), "the interceptor's context should still descend from ss's original")
+	require.EqualError(t, err, "stream failed")
+}
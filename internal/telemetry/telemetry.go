@@ -0,0 +1,145 @@
+// Package telemetry installs the OpenTelemetry tracer and meter
+// providers the rest of the module instruments against (internal/log,
+// internal/server), so operators can point a running node at stdout for
+// local debugging, an OTLP collector, or scrape it directly with
+// Prometheus.
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/prometheus"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+)
+
+// Exporter selects where spans and metrics are sent.
+type Exporter string
+
+const (
+	ExporterStdout     Exporter = "stdout"
+	ExporterOTLP       Exporter = "otlp"
+	ExporterPrometheus Exporter = "prometheus" // metrics only; traces still go to stdout
+)
+
+// Config configures Setup.
+type Config struct {
+	ServiceName string
+	Exporter    Exporter
+	// OTLPEndpoint is the collector address used when Exporter is
+	// ExporterOTLP, e.g. "localhost:4317".
+	OTLPEndpoint string
+}
+
+// Shutdown flushes and stops whatever providers Setup installed. Callers
+// should defer it and pass a context with a short timeout.
+type Shutdown func(context.Context) error
+
+// Setup installs a TracerProvider and MeterProvider for cfg.Exporter as
+// the global providers (otel.SetTracerProvider / otel.SetMeterProvider),
+// so every package that calls otel.Tracer/otel.Meter picks them up
+// without needing the providers threaded through. When cfg.Exporter is
+// ExporterPrometheus, the returned handler must be mounted (typically at
+// /metrics) for the metrics to be scraped; it's nil otherwise.
+func Setup(ctx context.Context, cfg Config) (Shutdown, http.Handler, error) {
+	res, err := resource.Merge(
+		resource.Default(),
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(cfg.ServiceName)),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tp, traceShutdown, err := newTracerProvider(ctx, cfg, res)
+	if err != nil {
+		return nil, nil, err
+	}
+	otel.SetTracerProvider(tp)
+
+	mp, metricsHandler, metricsShutdown, err := newMeterProvider(cfg, res)
+	if err != nil {
+		return nil, nil, err
+	}
+	otel.SetMeterProvider(mp)
+
+	shutdown := func(ctx context.Context) error {
+		if err := traceShutdown(ctx); err != nil {
+			return err
+		}
+		return metricsShutdown(ctx)
+	}
+	return shutdown, metricsHandler, nil
+}
+
+func newTracerProvider(ctx context.Context, cfg Config, res *resource.Resource) (*sdktrace.TracerProvider, Shutdown, error) {
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case ExporterOTLP:
+		exporter, err = otlptracegrpc.New(ctx, otlptracegrpc.WithEndpoint(cfg.OTLPEndpoint), otlptracegrpc.WithInsecure())
+	case ExporterStdout, ExporterPrometheus, "":
+		exporter, err = stdouttrace.New()
+	default:
+		return nil, nil, fmt.Errorf("telemetry: unknown exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	return tp, tp.Shutdown, nil
+}
+
+func newMeterProvider(cfg Config, res *resource.Resource) (*metric.MeterProvider, http.Handler, Shutdown, error) {
+	switch cfg.Exporter {
+	case ExporterPrometheus:
+		exporter, err := prometheus.New()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		mp := metric.NewMeterProvider(metric.WithReader(exporter), metric.WithResource(res))
+		return mp, promHandler(), mp.Shutdown, nil
+	case ExporterOTLP:
+		exporter, err := newOTLPMetricExporter(cfg)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		mp := metric.NewMeterProvider(metric.WithReader(metric.NewPeriodicReader(exporter)), metric.WithResource(res))
+		return mp, nil, mp.Shutdown, nil
+	case ExporterStdout, "":
+		exporter, err := stdoutmetric.New()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		mp := metric.NewMeterProvider(metric.WithReader(metric.NewPeriodicReader(exporter)), metric.WithResource(res))
+		return mp, nil, mp.Shutdown, nil
+	default:
+		return nil, nil, nil, fmt.Errorf("telemetry: unknown exporter %q", cfg.Exporter)
+	}
+}
+
+func newOTLPMetricExporter(cfg Config) (metric.Exporter, error) {
+	return otlpmetricgrpc.New(context.Background(),
+		otlpmetricgrpc.WithEndpoint(cfg.OTLPEndpoint),
+		otlpmetricgrpc.WithInsecure(),
+	)
+}
+
+// promHandler serves the Prometheus exposition format expected at
+// /metrics. It reads from the default registry, which is where otel's
+// prometheus exporter registers the Collector it returns.
+func promHandler() http.Handler {
+	return promhttp.Handler()
+}